@@ -0,0 +1,293 @@
+/* Copyright 2017 LinkedIn Corp. Licensed under the Apache License, Version
+ * 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ */
+
+package consumer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/linkedin/Burrow/core/internal/helpers"
+	"github.com/linkedin/Burrow/core/protocol"
+)
+
+// coordinatorRefreshGroupDelay is the pause between groups during a metadata poll, so that a full walk of a large
+// group list doesn't hammer the coordinator broker with a burst of requests.
+const coordinatorRefreshGroupDelay = 10 * time.Millisecond
+
+// KafkaCoordinatorClient is a consumer module which is a sibling of KafkaZkClient: rather than watching the
+// /consumers tree in Zookeeper, it reads group membership and committed offsets directly from the group
+// coordinator via sarama's ClusterAdmin, for groups that have moved off ZK-based offset storage (including hybrid
+// deployments where assignment is coordinator-managed but offsets are still committed to ZK by legacy tools).
+//
+// It never joins any of the groups it monitors. An earlier version of this module joined each group as its own
+// read-only sarama.ConsumerGroup member, but that meant session.Claims() only ever returned the partitions assigned
+// to Burrow's own shadow member (never the real members' assignments), so it could not report a genuine partition
+// owner; worse, by joining with the real group ID it took part in that group's rebalances, bumping real consumers
+// off partitions and forcing extra rebalances in production every time Burrow started or stopped. Polling
+// DescribeConsumerGroups and ListConsumerGroupOffsets instead is read-only: Burrow never becomes a member, and the
+// data it reports is the coordinator's own view of the group rather than an approximation derived from a shadow
+// session.
+type KafkaCoordinatorClient struct {
+	// App is a pointer to the application context. This stores the channel to the storage subsystem
+	App *protocol.ApplicationContext
+
+	// Log is a logger that has been configured for this module to use. Normally, this means it has been set up
+	// with fields that are appropriate to identify this coordinator
+	Log *zap.Logger
+
+	name            string
+	cluster         string
+	groups          []string
+	topics          []string
+	servers         []string
+	clientID        string
+	refreshInterval time.Duration
+
+	admin       sarama.ClusterAdmin
+	running     *sync.WaitGroup
+	stopRefresh chan struct{}
+
+	newClusterAdmin func([]string, *sarama.Config) (sarama.ClusterAdmin, error)
+}
+
+// Configure validates the configuration for the consumer. At minimum, there must be a cluster name, a list of
+// Kafka broker addresses, at least one consumer group to monitor, and at least one topic to fetch offsets for.
+func (module *KafkaCoordinatorClient) Configure(name string, configRoot string) {
+	module.Log.Info("configuring")
+
+	module.name = name
+	module.running = &sync.WaitGroup{}
+	module.newClusterAdmin = sarama.NewClusterAdmin
+
+	module.cluster = viper.GetString(configRoot + ".cluster")
+	module.servers = viper.GetStringSlice(configRoot + ".servers")
+	if len(module.servers) == 0 {
+		panic("No Kafka broker servers specified for consumer " + module.name)
+	} else if !helpers.ValidateHostList(module.servers) {
+		panic("Consumer '" + name + "' has one or more improperly formatted servers (must be host:port)")
+	}
+
+	module.groups = viper.GetStringSlice(configRoot + ".groups")
+	if len(module.groups) == 0 {
+		panic("Consumer '" + name + "' has no groups configured to monitor")
+	}
+
+	module.topics = viper.GetStringSlice(configRoot + ".topics")
+	if len(module.topics) == 0 {
+		panic("Consumer '" + name + "' has no topics configured to fetch offsets for")
+	}
+
+	viper.SetDefault(configRoot+".client-id", "burrow-"+name)
+	module.clientID = viper.GetString(configRoot + ".client-id")
+
+	viper.SetDefault(configRoot+".refresh-interval", 60)
+	module.refreshInterval = time.Duration(viper.GetInt(configRoot+".refresh-interval")) * time.Second
+}
+
+// Start opens a ClusterAdmin connection to the cluster and starts a goroutine that polls group membership and
+// offsets on refreshInterval. Any error connecting is returned to the caller.
+func (module *KafkaCoordinatorClient) Start() error {
+	module.Log.Info("starting")
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = module.clientID
+
+	admin, err := module.newClusterAdmin(module.servers, saramaConfig)
+	if err != nil {
+		return err
+	}
+	module.admin = admin
+
+	module.stopRefresh = make(chan struct{})
+	module.running.Add(1)
+	go module.refreshLoop()
+
+	return nil
+}
+
+// Stop closes the underlying ClusterAdmin connection.
+func (module *KafkaCoordinatorClient) Stop() error {
+	module.Log.Info("stopping")
+
+	close(module.stopRefresh)
+	module.running.Wait()
+
+	if err := module.admin.Close(); err != nil {
+		module.Log.Error("failed to close cluster admin", zap.String("error", err.Error()))
+	}
+
+	return nil
+}
+
+// refreshLoop polls the configured groups on refreshInterval until module.stopRefresh is closed.
+func (module *KafkaCoordinatorClient) refreshLoop() {
+	defer module.running.Done()
+
+	ticker := time.NewTicker(module.refreshInterval)
+	defer ticker.Stop()
+
+	module.reconcile()
+	for {
+		select {
+		case <-module.stopRefresh:
+			return
+		case <-ticker.C:
+			module.reconcile()
+		}
+	}
+}
+
+// reconcile describes each configured group and fetches its committed offsets for the configured topics, rate
+// limited with a small delay between groups so a large group list isn't hammered all at once.
+func (module *KafkaCoordinatorClient) reconcile() {
+	descriptions, err := module.admin.DescribeConsumerGroups(module.groups)
+	if err != nil {
+		module.Log.Error("failed to describe groups", zap.String("error", err.Error()))
+		return
+	}
+
+	topicPartitions, err := module.topicPartitions()
+	if err != nil {
+		module.Log.Error("failed to describe topics", zap.String("error", err.Error()))
+		return
+	}
+
+	for _, group := range descriptions {
+		module.reportMembership(group)
+		module.reportOffsets(group.GroupId, topicPartitions)
+		time.Sleep(coordinatorRefreshGroupDelay)
+	}
+}
+
+// topicPartitions describes the configured topics and returns the partition list for each, for use as the
+// topicPartitions argument to ListConsumerGroupOffsets.
+func (module *KafkaCoordinatorClient) topicPartitions() (map[string][]int32, error) {
+	topicMetadata, err := module.admin.DescribeTopics(module.topics)
+	if err != nil {
+		return nil, err
+	}
+
+	topicPartitions := make(map[string][]int32, len(topicMetadata))
+	for _, topic := range topicMetadata {
+		if topic.Err != sarama.ErrNoError {
+			module.Log.Warn("failed to describe topic",
+				zap.String("topic", topic.Name),
+				zap.String("error", topic.Err.Error()),
+			)
+			continue
+		}
+		partitions := make([]int32, len(topic.Partitions))
+		for i, partition := range topic.Partitions {
+			partitions[i] = partition.ID
+		}
+		topicPartitions[topic.Name] = partitions
+	}
+	return topicPartitions, nil
+}
+
+// reportMembership sends the live member count for a group, and the partition ownership the coordinator reports for
+// each of its members, decoded from each member's assignment.
+func (module *KafkaCoordinatorClient) reportMembership(group *sarama.GroupDescription) {
+	if group.Err != sarama.ErrNoError {
+		module.Log.Warn("failed to describe group",
+			zap.String("group", group.GroupId),
+			zap.String("error", group.Err.Error()),
+		)
+		return
+	}
+
+	membership := &protocol.StorageRequest{
+		RequestType: protocol.StorageSetConsumerMembership,
+		Cluster:     module.cluster,
+		Group:       group.GroupId,
+		MemberCount: int32(len(group.Members)),
+	}
+	module.Log.Debug("consumer membership",
+		zap.String("group", group.GroupId),
+		zap.Int("members", len(group.Members)),
+	)
+	helpers.TimeoutSendStorageRequest(module.App.StorageChannel, membership, 1)
+
+	for _, member := range group.Members {
+		assignment, err := member.GetMemberAssignment()
+		if err != nil {
+			module.Log.Warn("failed to decode member assignment",
+				zap.String("group", group.GroupId),
+				zap.String("member", member.ClientId),
+				zap.String("error", err.Error()),
+			)
+			continue
+		}
+
+		owner := member.ClientHost + "/" + member.ClientId
+		for topic, partitions := range assignment.Topics {
+			for _, partition := range partitions {
+				partitionOwner := &protocol.StorageRequest{
+					RequestType: protocol.StorageSetConsumerOwner,
+					Cluster:     module.cluster,
+					Topic:       topic,
+					Partition:   partition,
+					Group:       group.GroupId,
+					Owner:       owner,
+				}
+				module.Log.Debug("consumer owner",
+					zap.String("group", group.GroupId),
+					zap.String("topic", topic),
+					zap.Int32("partition", partition),
+					zap.String("owner", owner),
+				)
+				helpers.TimeoutSendStorageRequest(module.App.StorageChannel, partitionOwner, 1)
+			}
+		}
+	}
+}
+
+// reportOffsets fetches the coordinator's committed offsets for a group across the configured topics and forwards
+// each one to the storage module.
+func (module *KafkaCoordinatorClient) reportOffsets(group string, topicPartitions map[string][]int32) {
+	offsets, err := module.admin.ListConsumerGroupOffsets(group, topicPartitions)
+	if err != nil {
+		module.Log.Error("failed to list group offsets", zap.String("group", group), zap.String("error", err.Error()))
+		return
+	}
+
+	now := time.Now().Unix()
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			block := offsets.GetBlock(topic, partition)
+			if block == nil || block.Err != sarama.ErrNoError || block.Offset < 0 {
+				// No committed offset for this group on this partition yet
+				continue
+			}
+
+			partitionOffset := &protocol.StorageRequest{
+				RequestType: protocol.StorageSetConsumerOffset,
+				Cluster:     module.cluster,
+				Topic:       topic,
+				Partition:   partition,
+				Group:       group,
+				Timestamp:   now,
+				Offset:      block.Offset,
+			}
+			module.Log.Debug("consumer offset",
+				zap.String("group", group),
+				zap.String("topic", topic),
+				zap.Int32("partition", partition),
+				zap.Int64("offset", block.Offset),
+			)
+			helpers.TimeoutSendStorageRequest(module.App.StorageChannel, partitionOffset, 1)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 type topicList struct {
 	topics map[string]*partitionCount
 	lock   *sync.Mutex
+	format string
 }
 type partitionCount struct {
 	count int32
@@ -41,6 +42,16 @@ type Transform struct {
 	Offset int64 `json:"offset"`
 }
 
+// Supported values for the offset-format config. In legacy mode, the group's offsets are read from the standard
+// ZK-based consumer layout (/consumers/<group>/offsets/<topic>/<partition>, ASCII int64 payload) as written by
+// kazoo-go and other vanilla ZK consumers. In transform mode, the custom Transform JSON layout used internally is
+// kept as-is. In auto mode, the format is probed once per group and cached in topicList.format.
+const (
+	offsetFormatLegacy    = "legacy"
+	offsetFormatTransform = "transform"
+	offsetFormatAuto      = "auto"
+)
+
 // KafkaZkClient is a consumer module which connects to the Zookeeper ensemble where an Apache Kafka cluster maintains
 // metadata, and reads consumer group information from the /consumers tree (older ZK-based consumers). It uses watches
 // to monitor every group and offset, and the information is forwarded to the storage subsystem for use in evaluations.
@@ -57,6 +68,8 @@ type KafkaZkClient struct {
 	servers          []string
 	zookeeperTimeout int
 	zookeeperPath    string
+	offsetFormat     string
+	refreshInterval  time.Duration
 
 	zk             protocol.ZookeeperClient
 	areWatchesSet  bool
@@ -66,8 +79,13 @@ type KafkaZkClient struct {
 	groupWhitelist *regexp.Regexp
 	groupBlacklist *regexp.Regexp
 	connectFunc    func([]string, time.Duration, *zap.Logger) (protocol.ZookeeperClient, <-chan zk.Event, error)
+	stopRefresh    chan struct{}
 }
 
+// metadataRefreshGroupDelay is the pause between groups during a periodic metadata refresh, so that a full walk of
+// a large ensemble doesn't hammer Zookeeper with a burst of reads.
+const metadataRefreshGroupDelay = 10 * time.Millisecond
+
 // Configure validates the configuration for the consumer. At minimum, there must be a cluster name to which these
 // consumers belong, as well as a list of servers provided for the Zookeeper ensemble, of the form host:port. If not
 // explicitly configured, it is assumed that the Kafka cluster metadata is present in the ensemble root path. If the
@@ -98,6 +116,18 @@ func (module *KafkaZkClient) Configure(name string, configRoot string) {
 		panic("Consumer '" + name + "' has a bad zookeeper path configuration")
 	}
 
+	viper.SetDefault(configRoot+".offset-format", offsetFormatAuto)
+	module.offsetFormat = viper.GetString(configRoot + ".offset-format")
+	switch module.offsetFormat {
+	case offsetFormatLegacy, offsetFormatTransform, offsetFormatAuto:
+		// OK
+	default:
+		panic("Consumer '" + name + "' has an invalid offset-format (must be legacy, transform, or auto)")
+	}
+
+	viper.SetDefault(configRoot+".metadata-refresh-interval", 600)
+	module.refreshInterval = time.Duration(viper.GetInt(configRoot+".metadata-refresh-interval")) * time.Second
+
 	whitelist := viper.GetString(configRoot + ".group-whitelist")
 	if whitelist != "" {
 		re, err := regexp.Compile(whitelist)
@@ -141,6 +171,12 @@ func (module *KafkaZkClient) Start() error {
 	module.running.Add(1)
 	go module.connectionStateWatcher(connEventChan)
 
+	// Start up a func to periodically reconcile our state against Zookeeper, in case a watch event was ever
+	// dropped (session churn between Expired->Connected, a slow goroutine, a full event queue)
+	module.stopRefresh = make(chan struct{})
+	module.running.Add(1)
+	go module.metadataRefreshLoop()
+
 	return nil
 }
 
@@ -148,6 +184,8 @@ func (module *KafkaZkClient) Start() error {
 func (module *KafkaZkClient) Stop() error {
 	module.Log.Info("stopping")
 
+	close(module.stopRefresh)
+
 	// Closing the ZK client will invalidate all the watches, which will close all the running goroutines
 	module.zk.Close()
 	module.running.Wait()
@@ -215,9 +253,12 @@ func (module *KafkaZkClient) resetGroupListWatchAndAdd(resetOnly bool) {
 	go module.watchGroupList(groupListEventChan)
 
 	if !resetOnly {
-		// Check for any new groups and create the watches for them
+		// Check for any new groups and create the watches for them. groupLock is released before calling into
+		// resetTopicListWatchAndAdd/resetMemberListWatchAndAdd below (the same way reconcileMetadata's new-group
+		// branch does), since those eventually call back into groupTopicList, which takes groupLock itself -
+		// holding it across that call would deadlock, as sync.Mutex is not reentrant.
+		var newGroups []string
 		module.groupLock.Lock()
-		defer module.groupLock.Unlock()
 		for _, group := range consumerGroups {
 			if !module.acceptConsumerGroup(group) {
 				module.Log.Debug("skip group",
@@ -231,14 +272,22 @@ func (module *KafkaZkClient) resetGroupListWatchAndAdd(resetOnly bool) {
 				module.groupList[group] = &topicList{
 					topics: make(map[string]*partitionCount),
 					lock:   &sync.Mutex{},
+					format: module.offsetFormat,
 				}
 				module.Log.Debug("add group",
 					zap.String("group", group),
 				)
-				module.running.Add(1)
-				module.resetTopicListWatchAndAdd(group, false)
+				newGroups = append(newGroups, group)
 			}
 		}
+		module.groupLock.Unlock()
+
+		for _, group := range newGroups {
+			module.running.Add(1)
+			module.resetTopicListWatchAndAdd(group, false)
+			module.running.Add(1)
+			module.resetMemberListWatchAndAdd(group, false)
+		}
 	}
 }
 
@@ -254,8 +303,73 @@ func (module *KafkaZkClient) watchTopicList(group string, eventChan <-chan zk.Ev
 	go module.resetTopicListWatchAndAdd(group, event.Type != zk.EventNodeChildrenChanged)
 }
 
+// groupOffsetsPath returns the legacy ZK-based consumer offsets root for a group, i.e. /consumers/<group>/offsets.
+func (module *KafkaZkClient) groupOffsetsPath(group string) string {
+	return module.zookeeperPath + "/" + group + "/offsets"
+}
+
+// groupTopicList looks up a group's topicList under groupLock, so a concurrent write to module.groupList - a new
+// group being added, or the whole map being replaced on a session reset - can never race with this read.
+func (module *KafkaZkClient) groupTopicList(group string) *topicList {
+	module.groupLock.Lock()
+	defer module.groupLock.Unlock()
+	return module.groupList[group]
+}
+
+// resolveOffsetFormat returns the offset format to use for a group, probing and caching it in topicList.format the
+// first time an "auto" group is seen so the watcher path stays consistent across re-fires.
+func (module *KafkaZkClient) resolveOffsetFormat(group string) string {
+	list := module.groupTopicList(group)
+	list.lock.Lock()
+	defer list.lock.Unlock()
+
+	if list.format != offsetFormatAuto {
+		return list.format
+	}
+
+	if _, _, err := module.zk.Children(module.groupOffsetsPath(group)); err == nil {
+		module.Log.Debug("probed offset format", zap.String("group", group), zap.String("format", offsetFormatLegacy))
+		list.format = offsetFormatLegacy
+	} else {
+		module.Log.Debug("probed offset format", zap.String("group", group), zap.String("format", offsetFormatTransform))
+		list.format = offsetFormatTransform
+	}
+	return list.format
+}
+
 func (module *KafkaZkClient) resetTopicListWatchAndAdd(group string, resetOnly bool) {
 	defer module.running.Done()
+
+	if module.resolveOffsetFormat(group) == offsetFormatLegacy {
+		module.resetTopicListWatchAndAddLegacy(group, resetOnly)
+		return
+	}
+	module.resetTopicListWatchAndAddTransform(group, resetOnly)
+}
+
+// resetTopicListWatchAndAddLegacy discovers topics for a group via ChildrenW on /consumers/<group>/offsets, as
+// written by kazoo-go and other vanilla ZK-based consumers.
+func (module *KafkaZkClient) resetTopicListWatchAndAddLegacy(group string, resetOnly bool) {
+	groupTopics, _, topicListEventChan, err := module.zk.ChildrenW(module.groupOffsetsPath(group))
+	if err != nil {
+		module.Log.Debug("failed to get topic list",
+			zap.String("group", group),
+			zap.String("error", err.Error()),
+		)
+		return
+	}
+
+	module.running.Add(1)
+	go module.watchTopicList(group, topicListEventChan)
+
+	if !resetOnly {
+		module.addTopics(group, groupTopics)
+	}
+}
+
+// resetTopicListWatchAndAddTransform keeps the original Transform JSON behavior, where a group's single znode
+// contents name the one topic it consumes.
+func (module *KafkaZkClient) resetTopicListWatchAndAddTransform(group string, resetOnly bool) {
 	// changed by shengli |Get the current group topic list and reset our watch|
 	groupData, _, topicListEventChan, dataErr := module.zk.GetW(module.zookeeperPath + "/" + group + "/" + "0" )
 	if dataErr != nil {
@@ -275,31 +389,43 @@ func (module *KafkaZkClient) resetTopicListWatchAndAdd(group string, resetOnly b
 		return
 	}
 	// Get the current group topic list and reset our watch (transform group -> topic 1vs1)
-	groupTopics := [1]string{transformConsumer.Topic}
+	groupTopics := []string{transformConsumer.Topic}
 
 	// End changed by shengli |Get the current group topic list and reset our watch|
 	module.running.Add(1)
 	go module.watchTopicList(group, topicListEventChan)
 
 	if !resetOnly {
-		// Check for any new topics and create the watches for them
-		module.groupList[group].lock.Lock()
-		defer module.groupList[group].lock.Unlock()
-		for _, topic := range groupTopics {
-			if module.groupList[group].topics[topic] == nil {
-				module.groupList[group].topics[topic] = &partitionCount{
-					count: 0,
-					lock:  &sync.Mutex{},
-				}
-				module.Log.Debug("add topic",
-					zap.String("group", group),
-					zap.String("topic", topic),
-				)
-				module.running.Add(1)
-				module.resetPartitionListWatchAndAdd(group, topic, false)
+		module.addTopics(group, groupTopics)
+	}
+}
+
+// addTopics checks a newly observed topic list for any topics that aren't already being tracked, and starts the
+// partition watch for each of them.
+func (module *KafkaZkClient) addTopics(group string, groupTopics []string) {
+	list := module.groupTopicList(group)
+	var newTopics []string
+	list.lock.Lock()
+	for _, topic := range groupTopics {
+		if list.topics[topic] == nil {
+			list.topics[topic] = &partitionCount{
+				count: 0,
+				lock:  &sync.Mutex{},
 			}
+			module.Log.Debug("add topic",
+				zap.String("group", group),
+				zap.String("topic", topic),
+			)
+			newTopics = append(newTopics, topic)
 		}
 	}
+	list.lock.Unlock()
+
+	// list.lock is released first, as resetPartitionListWatchAndAdd takes it again via resolveOffsetFormat
+	for _, topic := range newTopics {
+		module.running.Add(1)
+		module.resetPartitionListWatchAndAdd(group, topic, false)
+	}
 }
 
 func (module *KafkaZkClient) watchPartitionList(group string, topic string, eventChan <-chan zk.Event) {
@@ -318,7 +444,13 @@ func (module *KafkaZkClient) resetPartitionListWatchAndAdd(group string, topic s
 	defer module.running.Done()
 	// changed by shengli
 	// Get the current topic partition list and reset our watch
-	topicPartitions, _, partitionListEventChan, err := module.zk.ChildrenW(module.zookeeperPath + "/" + group )
+	var partitionListPath string
+	if module.resolveOffsetFormat(group) == offsetFormatLegacy {
+		partitionListPath = module.groupOffsetsPath(group) + "/" + topic
+	} else {
+		partitionListPath = module.zookeeperPath + "/" + group
+	}
+	topicPartitions, _, partitionListEventChan, err := module.zk.ChildrenW(partitionListPath)
 	if err != nil {
 		// Can't read the consumers path. Bail for now
 		module.Log.Warn("failed to read partitions",
@@ -332,21 +464,33 @@ func (module *KafkaZkClient) resetPartitionListWatchAndAdd(group string, topic s
 	go module.watchPartitionList(group, topic, partitionListEventChan)
 
 	if !resetOnly {
-		// Check for any new partitions and create the watches for them
-		module.groupList[group].topics[topic].lock.Lock()
-		defer module.groupList[group].topics[topic].lock.Unlock()
-		if int32(len(topicPartitions)) >= module.groupList[group].topics[topic].count {
-			for i := module.groupList[group].topics[topic].count; i < int32(len(topicPartitions)); i++ {
-				module.Log.Debug("add partition",
-					zap.String("group", group),
-					zap.String("topic", topic),
-					zap.Int32("partition", i),
-				)
-				module.running.Add(1)
-				module.resetOffsetWatchAndSend(group, topic, i, false)
-			}
-			module.groupList[group].topics[topic].count = int32(len(topicPartitions))
+		module.addPartitions(group, topic, topicPartitions)
+	}
+}
+
+// addPartitions checks a newly observed partition list for any partitions that aren't already being tracked, and
+// starts the offset and owner watches for each of them.
+func (module *KafkaZkClient) addPartitions(group string, topic string, topicPartitions []string) {
+	list := module.groupTopicList(group)
+	list.lock.Lock()
+	partitions := list.topics[topic]
+	list.lock.Unlock()
+
+	partitions.lock.Lock()
+	defer partitions.lock.Unlock()
+	if int32(len(topicPartitions)) >= partitions.count {
+		for i := partitions.count; i < int32(len(topicPartitions)); i++ {
+			module.Log.Debug("add partition",
+				zap.String("group", group),
+				zap.String("topic", topic),
+				zap.Int32("partition", i),
+			)
+			module.running.Add(1)
+			module.resetOffsetWatchAndSend(group, topic, i, false)
+			module.running.Add(1)
+			module.resetOwnerListWatchAndAdd(group, topic, i, false)
 		}
+		partitions.count = int32(len(topicPartitions))
 	}
 }
 
@@ -365,8 +509,17 @@ func (module *KafkaZkClient) watchOffset(group string, topic string, partition i
 func (module *KafkaZkClient) resetOffsetWatchAndSend(group string, topic string, partition int32, resetOnly bool) {
 	defer module.running.Done()
 
+	legacy := module.resolveOffsetFormat(group) == offsetFormatLegacy
+
+	var offsetPath string
+	if legacy {
+		offsetPath = module.groupOffsetsPath(group) + "/" + topic + "/" + strconv.FormatInt(int64(partition), 10)
+	} else {
+		offsetPath = module.zookeeperPath + "/" + group + "/" + strconv.FormatInt(int64(partition), 10)
+	}
+
 	// Get the current offset and reset our watch
-	offsetString, offsetStat, offsetEventChan, err := module.zk.GetW(module.zookeeperPath + "/" + group + "/" + strconv.FormatInt(int64(partition), 10))
+	offsetString, offsetStat, offsetEventChan, err := module.zk.GetW(offsetPath)
 	if err != nil {
 		// Can't read the partition ofset path. Bail for now
 		module.Log.Warn("failed to read offset",
@@ -377,31 +530,46 @@ func (module *KafkaZkClient) resetOffsetWatchAndSend(group string, topic string,
 		)
 		return
 	}
-	var transformConsumer = Transform{}
-	formatErr := json.Unmarshal(offsetString, &transformConsumer)
-	if formatErr != nil {
-		module.Log.Debug("failed to marshal offset json of transform ",
-			zap.String("group", string(group)),
-			zap.String("error", formatErr.Error()),
-		)
-		// Badly formatted offset
-		module.Log.Error("badly formatted offset",
-			zap.String("group", group),
-			zap.String("topic", topic),
-			zap.Int32("partition", partition),
-			zap.ByteString("offset_string", offsetString),
-			zap.String("error", err.Error()),
-		)
-		return
-	}
 
+	var offset int64
+	if legacy {
+		// Legacy ZK consumers (kazoo-go, Burrow upstream, kafka_exporter) store the offset as an ASCII int64
+		offset, err = strconv.ParseInt(string(offsetString), 10, 64)
+		if err != nil {
+			module.Log.Error("badly formatted offset",
+				zap.String("group", group),
+				zap.String("topic", topic),
+				zap.Int32("partition", partition),
+				zap.ByteString("offset_string", offsetString),
+				zap.String("error", err.Error()),
+			)
+			return
+		}
+	} else {
+		var transformConsumer = Transform{}
+		formatErr := json.Unmarshal(offsetString, &transformConsumer)
+		if formatErr != nil {
+			module.Log.Debug("failed to marshal offset json of transform ",
+				zap.String("group", string(group)),
+				zap.String("error", formatErr.Error()),
+			)
+			// Badly formatted offset
+			module.Log.Error("badly formatted offset",
+				zap.String("group", group),
+				zap.String("topic", topic),
+				zap.Int32("partition", partition),
+				zap.ByteString("offset_string", offsetString),
+				zap.String("error", err.Error()),
+			)
+			return
+		}
+		offset = transformConsumer.Offset
+	}
 
 	module.running.Add(1)
 	go module.watchOffset(group, topic, partition, offsetEventChan)
 
 	if !resetOnly {
-		offset := transformConsumer.Offset
-
 		// Send the offset to the storage module
 		partitionOffset := &protocol.StorageRequest{
 			RequestType: protocol.StorageSetConsumerOffset,
@@ -422,3 +590,248 @@ func (module *KafkaZkClient) resetOffsetWatchAndSend(group string, topic string,
 		helpers.TimeoutSendStorageRequest(module.App.StorageChannel, partitionOffset, 1)
 	}
 }
+
+// groupOwnersPath returns the live partition-assignment root for a group, i.e. /consumers/<group>/owners, as written
+// by kazoo-go and other ZK-based consumer protocol implementations.
+func (module *KafkaZkClient) groupOwnersPath(group string) string {
+	return module.zookeeperPath + "/" + group + "/owners"
+}
+
+// groupIdsPath returns the ephemeral member-id root for a group, i.e. /consumers/<group>/ids.
+func (module *KafkaZkClient) groupIdsPath(group string) string {
+	return module.zookeeperPath + "/" + group + "/ids"
+}
+
+func (module *KafkaZkClient) watchOwner(group string, topic string, partition int32, eventChan <-chan zk.Event) {
+	defer module.running.Done()
+
+	event, isOpen := <-eventChan
+	if (!isOpen) || (event.Type == zk.EventNotWatching) {
+		// We're done here
+		return
+	}
+	module.running.Add(1)
+	go module.resetOwnerListWatchAndAdd(group, topic, partition, event.Type != zk.EventNodeDataChanged)
+}
+
+// resetOwnerListWatchAndAdd reads the owner string for a single partition from /consumers/<group>/owners/<topic>/
+// <partition> and forwards it to the storage module so the evaluator and HTTP API can report which consumer host
+// owns which partition.
+func (module *KafkaZkClient) resetOwnerListWatchAndAdd(group string, topic string, partition int32, resetOnly bool) {
+	defer module.running.Done()
+
+	ownerPath := module.groupOwnersPath(group) + "/" + topic + "/" + strconv.FormatInt(int64(partition), 10)
+	ownerString, _, ownerEventChan, err := module.zk.GetW(ownerPath)
+	if err != nil {
+		// No owner registered for this partition yet (or the consumer protocol doesn't use /owners). Bail for now
+		module.Log.Debug("failed to read owner",
+			zap.String("group", group),
+			zap.String("topic", topic),
+			zap.Int32("partition", partition),
+			zap.String("error", err.Error()),
+		)
+		return
+	}
+
+	module.running.Add(1)
+	go module.watchOwner(group, topic, partition, ownerEventChan)
+
+	if !resetOnly {
+		owner := string(ownerString)
+
+		partitionOwner := &protocol.StorageRequest{
+			RequestType: protocol.StorageSetConsumerOwner,
+			Cluster:     module.cluster,
+			Topic:       topic,
+			Partition:   partition,
+			Group:       group,
+			Owner:       owner,
+		}
+		module.Log.Debug("consumer owner",
+			zap.String("group", group),
+			zap.String("topic", topic),
+			zap.Int32("partition", partition),
+			zap.String("owner", owner),
+		)
+		helpers.TimeoutSendStorageRequest(module.App.StorageChannel, partitionOwner, 1)
+	}
+}
+
+func (module *KafkaZkClient) watchMemberList(group string, eventChan <-chan zk.Event) {
+	defer module.running.Done()
+
+	event, isOpen := <-eventChan
+	if (!isOpen) || (event.Type == zk.EventNotWatching) {
+		// We're done here
+		return
+	}
+	module.running.Add(1)
+	go module.resetMemberListWatchAndAdd(group, event.Type != zk.EventNodeChildrenChanged)
+}
+
+// resetMemberListWatchAndAdd counts the live ephemeral member znodes under /consumers/<group>/ids and forwards the
+// count as a StorageSetConsumerMembership request, alongside StorageSetConsumerOwner from resetOwnerListWatchAndAdd
+// above, so downstream consumers can flag a group as STOP when members==0 even if its offsets haven't moved (an
+// abandoned group rather than a merely stalled one), and can report which host owns which partition.
+func (module *KafkaZkClient) resetMemberListWatchAndAdd(group string, resetOnly bool) {
+	defer module.running.Done()
+
+	members, _, memberListEventChan, err := module.zk.ChildrenW(module.groupIdsPath(group))
+	if err != nil {
+		// No /ids node for this group (older clients never created it). Bail for now
+		module.Log.Debug("failed to read members",
+			zap.String("group", group),
+			zap.String("error", err.Error()),
+		)
+		return
+	}
+
+	module.running.Add(1)
+	go module.watchMemberList(group, memberListEventChan)
+
+	if !resetOnly {
+		membership := &protocol.StorageRequest{
+			RequestType: protocol.StorageSetConsumerMembership,
+			Cluster:     module.cluster,
+			Group:       group,
+			MemberCount: int32(len(members)),
+		}
+		module.Log.Debug("consumer membership",
+			zap.String("group", group),
+			zap.Int("members", len(members)),
+		)
+		helpers.TimeoutSendStorageRequest(module.App.StorageChannel, membership, 1)
+	}
+}
+
+// metadataRefreshLoop periodically walks the full /consumers tree and reconciles it against module.groupList, in
+// case a NodeChildrenChanged event was ever dropped. It stops when module.stopRefresh is closed.
+func (module *KafkaZkClient) metadataRefreshLoop() {
+	defer module.running.Done()
+
+	ticker := time.NewTicker(module.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-module.stopRefresh:
+			return
+		case <-ticker.C:
+			if !module.areWatchesSet {
+				// A session reset is already in flight (or about to be); let it finish adding groups instead of
+				// racing it with a periodic refresh of a now-stale groupList
+				module.Log.Debug("skipping metadata refresh, watches are being reinitialized")
+				continue
+			}
+			module.reconcileMetadata()
+		}
+	}
+}
+
+// reconcileMetadata does a full, non-watching walk of /consumers (and each group's topics and partitions),
+// reinjecting any group, topic, or partition that isn't already being tracked through the normal
+// resetXxxWatchAndAdd paths. It's rate-limited with a small delay between groups so a large ensemble isn't
+// hammered with reads.
+func (module *KafkaZkClient) reconcileMetadata() {
+	consumerGroups, err := module.zk.Children(module.zookeeperPath)
+	if err != nil {
+		module.Log.Error("metadata refresh: failed to list groups", zap.String("error", err.Error()))
+		return
+	}
+
+	for _, group := range consumerGroups {
+		if !module.acceptConsumerGroup(group) {
+			continue
+		}
+
+		module.groupLock.Lock()
+		if module.groupList[group] == nil {
+			module.groupList[group] = &topicList{
+				topics: make(map[string]*partitionCount),
+				lock:   &sync.Mutex{},
+				format: module.offsetFormat,
+			}
+			module.groupLock.Unlock()
+
+			module.Log.Info("metadata refresh: found missed group", zap.String("group", group))
+			module.running.Add(1)
+			module.resetTopicListWatchAndAdd(group, false)
+			module.running.Add(1)
+			module.resetMemberListWatchAndAdd(group, false)
+		} else {
+			list := module.groupList[group]
+			module.groupLock.Unlock()
+			module.reconcileGroupTopics(group, list)
+		}
+
+		time.Sleep(metadataRefreshGroupDelay)
+	}
+}
+
+// reconcileGroupTopics diffs a single group's topics (and each topic's partitions) against what's already being
+// tracked, reinjecting anything that's missing. It never re-establishes a watch for an entry that's already being
+// tracked, so it can't race a live watch into duplicating itself.
+func (module *KafkaZkClient) reconcileGroupTopics(group string, list *topicList) {
+	var topics []string
+	var err error
+	if module.resolveOffsetFormat(group) == offsetFormatLegacy {
+		topics, err = module.zk.Children(module.groupOffsetsPath(group))
+		if err != nil {
+			module.Log.Debug("metadata refresh: failed to list topics",
+				zap.String("group", group),
+				zap.String("error", err.Error()),
+			)
+			return
+		}
+	} else {
+		groupData, getErr := module.zk.Get(module.zookeeperPath + "/" + group + "/" + "0")
+		if getErr != nil {
+			module.Log.Debug("metadata refresh: failed to get topic",
+				zap.String("group", group),
+				zap.String("error", getErr.Error()),
+			)
+			return
+		}
+		var transformConsumer Transform
+		if jsonErr := json.Unmarshal(groupData, &transformConsumer); jsonErr != nil {
+			module.Log.Debug("metadata refresh: failed to unmarshal transform",
+				zap.String("group", group),
+				zap.String("error", jsonErr.Error()),
+			)
+			return
+		}
+		topics = []string{transformConsumer.Topic}
+	}
+
+	for _, topic := range topics {
+		list.lock.Lock()
+		tracked := list.topics[topic]
+		list.lock.Unlock()
+
+		if tracked == nil {
+			module.Log.Info("metadata refresh: found missed topic", zap.String("group", group), zap.String("topic", topic))
+			module.addTopics(group, []string{topic})
+			continue
+		}
+
+		var partitionsPath string
+		if module.resolveOffsetFormat(group) == offsetFormatLegacy {
+			partitionsPath = module.groupOffsetsPath(group) + "/" + topic
+		} else {
+			partitionsPath = module.zookeeperPath + "/" + group
+		}
+		partitions, err := module.zk.Children(partitionsPath)
+		if err != nil {
+			module.Log.Debug("metadata refresh: failed to list partitions",
+				zap.String("group", group),
+				zap.String("topic", topic),
+				zap.String("error", err.Error()),
+			)
+			continue
+		}
+		if int32(len(partitions)) > tracked.count {
+			module.Log.Info("metadata refresh: found missed partitions", zap.String("group", group), zap.String("topic", topic))
+			module.addPartitions(group, topic, partitions)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"strconv"
+
+	"github.com/linkedin/Burrow/core/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// statusGauge maps the numeric encoding used for burrow_kafka_consumergroup_status. The ordering matches
+// protocol.StatusConstant so an evaluation's Status can be used directly as the gauge value.
+var statusGauge = map[protocol.StatusConstant]float64{
+	protocol.StatusOK:     0,
+	protocol.StatusWarn:   1,
+	protocol.StatusError:  2,
+	protocol.StatusStop:   3,
+	protocol.StatusStall:  4,
+	protocol.StatusRewind: 5,
+}
+
+// PrometheusSender is a sibling of TSDBSender that publishes consumer group evaluations as Prometheus gauges rather
+// than writing them to InfluxDB. It registers its gauges with a registry of its own rather than the global
+// prometheus.DefaultRegisterer, so a second instance (a second prometheus storage module, a config reload) can be
+// configured without panicking on a duplicate registration. A caller is expected to expose Registry() on a /metrics
+// endpoint via promhttp.HandlerFor (see the storage prometheus module).
+type PrometheusSender struct {
+	Log *zap.Logger
+
+	registry *prometheus.Registry
+
+	lag           *prometheus.GaugeVec
+	currentOffset *prometheus.GaugeVec
+	totalLag      *prometheus.GaugeVec
+	status        *prometheus.GaugeVec
+	logEndOffset  *prometheus.GaugeVec
+	members       *prometheus.GaugeVec
+	owner         *prometheus.GaugeVec
+}
+
+// Registry returns the Prometheus registry this sender's gauges are registered with, for use with
+// promhttp.HandlerFor. It is only valid after Config has been called.
+func (sender *PrometheusSender) Registry() *prometheus.Registry {
+	return sender.registry
+}
+
+// Config creates this sender's own Prometheus registry and registers the gauge vectors with it. It should be called
+// once, before the first call to SendTransformLags or SendLogEndOffset.
+func (sender *PrometheusSender) Config() {
+	sender.registry = prometheus.NewRegistry()
+
+	sender.lag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_consumergroup_lag",
+		Help: "Per-partition consumer lag, as reported by the last Burrow evaluation",
+	}, []string{"cluster", "group", "topic", "partition"})
+
+	sender.currentOffset = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_consumergroup_current_offset",
+		Help: "Per-partition committed consumer offset, as reported by the last Burrow evaluation",
+	}, []string{"cluster", "group", "topic", "partition"})
+
+	sender.totalLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_consumergroup_total_lag",
+		Help: "Total lag summed across all partitions owned by a consumer group",
+	}, []string{"cluster", "group"})
+
+	sender.status = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_consumergroup_status",
+		Help: "Consumer group status (0=OK, 1=WARN, 2=ERR, 3=STOP, 4=STALL, 5=REWIND)",
+	}, []string{"cluster", "group"})
+
+	sender.logEndOffset = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_topic_partition_log_end_offset",
+		Help: "Latest known log end (high water mark) offset for a topic partition",
+	}, []string{"cluster", "topic", "partition"})
+
+	sender.members = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_consumergroup_members",
+		Help: "Count of live ephemeral members registered for a consumer group",
+	}, []string{"cluster", "group"})
+
+	sender.owner = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "burrow_kafka_consumergroup_partition_owner",
+		Help: "Set to 1 for the owner currently assigned to a partition",
+	}, []string{"cluster", "group", "topic", "partition", "owner"})
+
+	sender.registry.MustRegister(sender.lag, sender.currentOffset, sender.totalLag, sender.status, sender.logEndOffset,
+		sender.members, sender.owner)
+}
+
+// SendTransformLags updates the consumer group gauges from a batch of evaluations, in the same shape that
+// TSDBSender.SendTransformLags consumes.
+func (sender *PrometheusSender) SendTransformLags(statusList []*protocol.ConsumerGroupStatus) {
+	for _, consumerGroupStatus := range statusList {
+		sender.totalLag.WithLabelValues(consumerGroupStatus.Cluster, consumerGroupStatus.Group).Set(float64(consumerGroupStatus.TotalLag))
+		sender.status.WithLabelValues(consumerGroupStatus.Cluster, consumerGroupStatus.Group).Set(statusGauge[consumerGroupStatus.Status])
+
+		for _, partition := range consumerGroupStatus.Partitions {
+			labels := prometheus.Labels{
+				"cluster":   consumerGroupStatus.Cluster,
+				"group":     consumerGroupStatus.Group,
+				"topic":     partition.Topic,
+				"partition": formatPartition(partition.Partition),
+			}
+			sender.lag.With(labels).Set(float64(partition.CurrentLag))
+			sender.currentOffset.With(labels).Set(float64(partition.Offset))
+		}
+	}
+}
+
+// SendLogEndOffset updates the log-end-offset gauge from the existing broker-offset storage path.
+func (sender *PrometheusSender) SendLogEndOffset(cluster string, topic string, partition int32, offset int64) {
+	sender.logEndOffset.WithLabelValues(cluster, topic, formatPartition(partition)).Set(float64(offset))
+}
+
+// SendMembership updates the live member count for a consumer group, fed from
+// protocol.StorageSetConsumerMembership requests.
+func (sender *PrometheusSender) SendMembership(cluster string, group string, memberCount int32) {
+	sender.members.WithLabelValues(cluster, group).Set(float64(memberCount))
+}
+
+// SendOwner updates the current owner for a partition, fed from protocol.StorageSetConsumerOwner requests. The
+// previous owner's series is left in place rather than removed, which is the same stale-series tradeoff the other
+// gauges in this sender already make.
+func (sender *PrometheusSender) SendOwner(cluster string, group string, topic string, partition int32, owner string) {
+	sender.owner.WithLabelValues(cluster, group, topic, formatPartition(partition), owner).Set(1)
+}
+
+func formatPartition(partition int32) string {
+	return strconv.Itoa(int(partition))
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/influxdata/influxdb/client/v2"
@@ -8,7 +9,20 @@ import (
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"strconv"
-	"strings"
+)
+
+// defaultTSDBTags and defaultTSDBFields reproduce the point schema this sender has always emitted, for deployments
+// that don't set tsdb.tags / tsdb.fields explicitly.
+var (
+	defaultTSDBTags   = []string{"appId", "cluster", "consumerGroup", "topic", "complete"}
+	defaultTSDBFields = []string{"partitionCount", "totalLag", "status"}
+)
+
+// Granularity controls how many points SendTransformLags emits per consumer group evaluation.
+const (
+	tsdbGranularityGroup     = "group"
+	tsdbGranularityTopic     = "topic"
+	tsdbGranularityPartition = "partition"
 )
 
 type TSDBSender struct {
@@ -17,6 +31,11 @@ type TSDBSender struct {
 	measurement string
 	database    string
 	Log         *zap.Logger
+
+	appIdRegex  *regexp.Regexp
+	tagNames    []string
+	fieldNames  []string
+	granularity string
 }
 
 func (sender *TSDBSender) Config(configRoot string) {
@@ -31,6 +50,173 @@ func (sender *TSDBSender) Config(configRoot string) {
 	sender.measurement = viper.GetString(configRoot + ".measurement")
 	sender.database = viper.GetString(configRoot + ".database")
 	sender.tsdbClient = c
+
+	if appIdRegexStr := viper.GetString(configRoot + ".group-appid-regex"); appIdRegexStr != "" {
+		re, reErr := regexp.Compile(appIdRegexStr)
+		if reErr != nil {
+			sender.Log.Error("invalid group-appid-regex, falling back to the full group name",
+				zap.String("tsdb error", reErr.Error()))
+		} else {
+			sender.appIdRegex = re
+		}
+	}
+
+	sender.tagNames = viper.GetStringSlice(configRoot + ".tags")
+	if len(sender.tagNames) == 0 {
+		sender.tagNames = defaultTSDBTags
+	}
+	sender.fieldNames = viper.GetStringSlice(configRoot + ".fields")
+	if len(sender.fieldNames) == 0 {
+		sender.fieldNames = defaultTSDBFields
+	}
+
+	viper.SetDefault(configRoot+".point-granularity", tsdbGranularityGroup)
+	sender.granularity = viper.GetString(configRoot + ".point-granularity")
+}
+
+// extractAppId splits the appId out of a group name using the configured group-appid-regex (a named capture group
+// "appid"). Groups that don't follow whatever naming convention the regex encodes fall back to the full group name,
+// rather than silently producing a garbage tag.
+func (sender *TSDBSender) extractAppId(group string) string {
+	if sender.appIdRegex == nil {
+		return group
+	}
+	match := sender.appIdRegex.FindStringSubmatch(group)
+	if match == nil {
+		return group
+	}
+	for i, name := range sender.appIdRegex.SubexpNames() {
+		if name == "appid" {
+			return match[i]
+		}
+	}
+	return group
+}
+
+// tagValue resolves one declarative tag name against a consumer group evaluation, and optionally a single
+// partition when emitting per-partition or per-topic points. Unrecognized tag names are skipped by the caller.
+func (sender *TSDBSender) tagValue(status *protocol.ConsumerGroupStatus, partition *protocol.PartitionStatus, name string) (string, bool) {
+	switch name {
+	case "appId":
+		return sender.extractAppId(status.Group), true
+	case "cluster":
+		return status.Cluster, true
+	case "consumerGroup":
+		return status.Group, true
+	case "complete":
+		return strconv.FormatFloat(float64(status.Complete), 'E', -1, 32), true
+	case "topic":
+		if partition != nil {
+			return partition.Topic, true
+		}
+		if status.Maxlag != nil {
+			return status.Maxlag.Topic, true
+		}
+		return "", false
+	case "partition":
+		if partition == nil {
+			return "", false
+		}
+		return strconv.FormatInt(int64(partition.Partition), 10), true
+	default:
+		return "", false
+	}
+}
+
+// fieldValue resolves one declarative field name the same way tagValue resolves tag names.
+func (sender *TSDBSender) fieldValue(status *protocol.ConsumerGroupStatus, partition *protocol.PartitionStatus, name string) (interface{}, bool) {
+	switch name {
+	case "partitionCount":
+		return status.TotalPartitions, true
+	case "totalLag":
+		return int(status.TotalLag), true
+	case "status":
+		return status.Status, true
+	case "offset":
+		if partition == nil {
+			return nil, false
+		}
+		return partition.Offset, true
+	case "lag":
+		if partition == nil {
+			return nil, false
+		}
+		return partition.CurrentLag, true
+	default:
+		return nil, false
+	}
+}
+
+func (sender *TSDBSender) buildTags(status *protocol.ConsumerGroupStatus, partition *protocol.PartitionStatus) map[string]string {
+	tags := make(map[string]string, len(sender.tagNames))
+	for _, name := range sender.tagNames {
+		if value, ok := sender.tagValue(status, partition, name); ok {
+			tags[name] = value
+		}
+	}
+	return tags
+}
+
+func (sender *TSDBSender) buildFields(status *protocol.ConsumerGroupStatus, partition *protocol.PartitionStatus) map[string]interface{} {
+	fields := make(map[string]interface{}, len(sender.fieldNames))
+	for _, name := range sender.fieldNames {
+		if value, ok := sender.fieldValue(status, partition, name); ok {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+type tsdbPoint struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// pointsForStatus expands a single evaluation into the points to emit, per the configured point-granularity:
+// one aggregated point for the whole group (the historical behavior), one point per topic, or one point per
+// partition. The tags/fields on each point are still driven by the declarative tsdb.tags / tsdb.fields config.
+func (sender *TSDBSender) pointsForStatus(status *protocol.ConsumerGroupStatus) []tsdbPoint {
+	switch sender.granularity {
+	case tsdbGranularityPartition:
+		points := make([]tsdbPoint, 0, len(status.Partitions))
+		for _, partition := range status.Partitions {
+			points = append(points, tsdbPoint{
+				tags:   sender.buildTags(status, partition),
+				fields: sender.buildFields(status, partition),
+			})
+		}
+		return points
+	case tsdbGranularityTopic:
+		topicPartitions := make(map[string][]*protocol.PartitionStatus)
+		var topicOrder []string
+		for _, partition := range status.Partitions {
+			if _, ok := topicPartitions[partition.Topic]; !ok {
+				topicOrder = append(topicOrder, partition.Topic)
+			}
+			topicPartitions[partition.Topic] = append(topicPartitions[partition.Topic], partition)
+		}
+
+		points := make([]tsdbPoint, 0, len(topicOrder))
+		for _, topic := range topicOrder {
+			partitions := topicPartitions[topic]
+			var totalLag, totalOffset int64
+			for _, partition := range partitions {
+				totalLag += partition.CurrentLag
+				totalOffset += partition.Offset
+			}
+			representative := &protocol.PartitionStatus{Topic: topic, CurrentLag: totalLag, Offset: totalOffset}
+			points = append(points, tsdbPoint{
+				tags:   sender.buildTags(status, representative),
+				fields: sender.buildFields(status, representative),
+			})
+		}
+		return points
+	default:
+		return []tsdbPoint{{
+			tags:   sender.buildTags(status, nil),
+			fields: sender.buildFields(status, nil),
+		}}
+	}
 }
 
 func (sender *TSDBSender) SendTransformLags(statusList []*protocol.ConsumerGroupStatus) {
@@ -44,22 +230,12 @@ func (sender *TSDBSender) SendTransformLags(statusList []*protocol.ConsumerGroup
 	}
 
 	for _, consumerGroupStatus := range statusList {
-		if consumerGroupStatus.Maxlag != nil {
-			appId := strings.Split(consumerGroupStatus.Group, "-")[0]
-			tags := map[string]string{
-				"appId": 		 appId,
-				"cluster":       consumerGroupStatus.Cluster,
-				"consumerGroup": consumerGroupStatus.Group,
-				"topic":         consumerGroupStatus.Maxlag.Topic,
-				"complete":      strconv.FormatFloat(float64(consumerGroupStatus.Complete), 'E', -1, 32),
+		if consumerGroupStatus.Maxlag == nil {
+			continue
+		}
 
-			}
-			fields := map[string]interface{}{
-				"partitionCount": consumerGroupStatus.TotalPartitions,
-				"totalLag":       int(consumerGroupStatus.TotalLag),
-				"status":         consumerGroupStatus.Status,
-			}
-			pt, err := client.NewPoint(sender.measurement, tags, fields, time.Now())
+		for _, point := range sender.pointsForStatus(consumerGroupStatus) {
+			pt, err := client.NewPoint(sender.measurement, point.tags, point.fields, time.Now())
 			if err != nil {
 				sender.Log.Error("error create point", zap.String("tsdb error", err.Error()))
 				return
@@ -0,0 +1,114 @@
+/* Copyright 2017 LinkedIn Corp. Licensed under the Apache License, Version
+ * 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ */
+
+package storage
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/linkedin/Burrow/core/internal/helpers"
+	"github.com/linkedin/Burrow/core/protocol"
+)
+
+// PrometheusStorage is a storage module that exposes broker offsets as Prometheus gauges on a /metrics HTTP
+// endpoint, for consumption by the Kafka-exporter ecosystem. Unlike KafkaCoordinatorClient or KafkaZkClient, it
+// does not talk to Kafka or Zookeeper itself.
+//
+// It must NOT range over App.StorageChannel itself: that channel has exactly one consumer, the primary in-memory
+// storage module, and a second reader would silently steal half of every broker/consumer-offset request away from
+// it instead of observing a copy. Instead, the storage coordinator is expected to call HandleRequest for every
+// registered module (this one included) as it dispatches each request it reads off StorageChannel - the same way
+// it already fans requests out to the primary storage backend.
+type PrometheusStorage struct {
+	// App is a pointer to the application context. This stores the channel to the storage subsystem
+	App *protocol.ApplicationContext
+
+	// Log is a logger that has been configured for this module to use. Normally, this means it has been set up
+	// with fields that are appropriate to identify this module
+	Log *zap.Logger
+
+	name    string
+	address string
+
+	sender  *helpers.PrometheusSender
+	server  *http.Server
+	running *sync.WaitGroup
+}
+
+// Configure validates the configuration for the module. The only setting is the address to listen on for the
+// /metrics endpoint, which defaults to ":8181"; if it is explicitly set to an empty string, this func will panic.
+func (module *PrometheusStorage) Configure(name string, configRoot string) {
+	module.Log.Info("configuring")
+
+	module.name = name
+	module.running = &sync.WaitGroup{}
+
+	viper.SetDefault(configRoot+".address", ":8181")
+	module.address = viper.GetString(configRoot + ".address")
+	if module.address == "" {
+		panic("Prometheus storage module '" + name + "' has no address configured")
+	}
+
+	module.sender = &helpers.PrometheusSender{Log: module.Log}
+	module.sender.Config()
+}
+
+// Start registers the /metrics handler and starts the HTTP listener. It does not consume StorageChannel itself -
+// see HandleRequest.
+func (module *PrometheusStorage) Start() error {
+	module.Log.Info("starting")
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(module.sender.Registry(), promhttp.HandlerOpts{}))
+	module.server = &http.Server{Addr: module.address, Handler: mux}
+
+	module.running.Add(1)
+	go func() {
+		defer module.running.Done()
+		if err := module.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			module.Log.Error("metrics server stopped", zap.String("error", err.Error()))
+		}
+	}()
+
+	return nil
+}
+
+// Stop shuts down the metrics HTTP server.
+func (module *PrometheusStorage) Stop() error {
+	module.Log.Info("stopping")
+
+	if err := module.server.Close(); err != nil {
+		module.Log.Error("failed to close metrics server", zap.String("error", err.Error()))
+	}
+	module.running.Wait()
+
+	return nil
+}
+
+// HandleRequest is called by the storage coordinator for every request it reads off StorageChannel, the same way
+// it dispatches to the primary in-memory storage backend, so this module sees a copy of each request rather than
+// competing with the primary backend for it. Consumer group evaluations (lag, total lag, status) don't come
+// through here - they reach the PrometheusSender the same way they reach TSDBSender, through the notifier
+// pipeline's SendTransformLags call once an evaluation completes.
+func (module *PrometheusStorage) HandleRequest(request *protocol.StorageRequest) {
+	switch request.RequestType {
+	case protocol.StorageSetBrokerOffset:
+		module.sender.SendLogEndOffset(request.Cluster, request.Topic, request.Partition, request.Offset)
+	case protocol.StorageSetConsumerOwner:
+		module.sender.SendOwner(request.Cluster, request.Group, request.Topic, request.Partition, request.Owner)
+	case protocol.StorageSetConsumerMembership:
+		module.sender.SendMembership(request.Cluster, request.Group, request.MemberCount)
+	}
+}